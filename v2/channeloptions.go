@@ -0,0 +1,25 @@
+package emitter
+
+import "strconv"
+
+// Option is a channel query-string option, such as a TTL or history depth,
+// appended to the topic a Publish, Subscribe or link request is issued
+// against.
+type Option interface {
+	String() string
+}
+
+type option string
+
+func (o option) String() string { return string(o) }
+
+// WithTTL sets a Time-To-Live, in seconds, on a published message.
+func WithTTL(seconds int) Option {
+	return option("ttl=" + strconv.Itoa(seconds))
+}
+
+// WithLast requests the specified number of retained messages be replayed
+// on subscribe.
+func WithLast(count int) Option {
+	return option("last=" + strconv.Itoa(count))
+}
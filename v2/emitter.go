@@ -4,9 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -32,17 +32,31 @@ type Client struct {
 	guid       string              // Emiter's client ID
 	conn       mqtt.Client         // MQTT client
 	opts       *mqtt.ClientOptions // MQTT options
-	store      *store              // In-flight requests store
+	store      Persistence         // In-flight requests store
 	handlers   *trie               // The registry for handlers
 	timeout    time.Duration       // Default timeout
+	configErr  error               // Set by an option that failed, surfaced on Connect
 	message    MessageHandler      // User-defined message handler
 	connect    ConnectHandler      // User-defined connect handler
 	disconnect DisconnectHandler   // User-defined disconnect handler
 	presence   PresenceHandler     // User-defined presence handler
 	errors     ErrorHandler        // User-defined error handler
+	logger     Logger              // Destination for library-side diagnostics
+	middleware []Middleware        // Chain wrapped around every dispatched message
+
+	connectedOnce     bool             // Set once the first successful connect has fired
+	reconnecting      ReconnectHandler // User-defined reconnecting handler
+	reconnectAttempts uint32           // Attempt counter, reset on every successful (re)connect
+	pending           int32            // Count of Publish calls awaiting acknowledgement
+
+	subsMu sync.Mutex              // Guards subs and links
+	subs   map[string]subscription // Replayed on reconnect
+	links  map[string]activeLink   // Replayed on reconnect, keyed by link name
 }
 
 // Connect is a convenience function which sets a broker and connects to it.
+// host may use the tcp://, ssl://, ws:// or wss:// scheme; emitter.io cloud
+// brokers listening on :443 require wss://.
 func Connect(host string, handler MessageHandler, options ...func(*Client)) (*Client, error) {
 	if len(host) > 0 {
 		options = append(options, WithBrokers(host))
@@ -65,13 +79,15 @@ func NewClient(options ...func(*Client)) *Client {
 	c := &Client{
 		opts:     mqtt.NewClientOptions(),
 		timeout:  60 * time.Second,
-		store:    new(store),
+		store:    newMemoryPersistence(),
 		handlers: newTrie(),
+		logger:   stdLogger{},
 	}
 
 	// Set handlers
 	c.opts.SetOnConnectHandler(c.onConnect)
 	c.opts.SetConnectionLostHandler(c.onConnectionLost)
+	c.opts.SetReconnectingHandler(c.onReconnecting)
 	c.opts.SetDefaultPublishHandler(c.onMessage)
 	c.opts.SetClientID(uuid())
 	c.opts.SetStore(c.store)
@@ -114,6 +130,11 @@ func (c *Client) OnPresence(handler PresenceHandler) {
 
 // onConnect occurs when MQTT client is connected
 func (c *Client) onConnect(_ mqtt.Client) {
+	if c.connectedOnce {
+		c.resubscribe()
+	}
+	c.connectedOnce = true
+
 	if c.connect != nil {
 		c.connect(c)
 	}
@@ -124,7 +145,7 @@ func (c *Client) onConnectionLost(_ mqtt.Client, e error) {
 	if c.disconnect != nil {
 		c.disconnect(c, e)
 	} else {
-		log.Println("emitter: connection lost, due to", e.Error())
+		c.logger.Warnf("connection lost, due to %v", e)
 	}
 }
 
@@ -135,8 +156,19 @@ func (c *Client) OnError(handler ErrorHandler) {
 	c.errors = handler
 }
 
-// onMessage occurs when MQTT client receives a message
+// onMessage occurs when MQTT client receives a message. It runs the
+// message through the middleware chain installed via Use before
+// dispatching it, so middleware such as metrics, tracing or panic recovery
+// see every message the client receives exactly once.
 func (c *Client) onMessage(_ mqtt.Client, m mqtt.Message) {
+	c.chain()(c, m)
+}
+
+// dispatch routes an incoming message to the appropriate handler: the
+// per-channel or default MessageHandler for ordinary channel messages, or
+// emitter's own presence/error/keygen/link/me handling for emitter/*
+// control messages.
+func (c *Client) dispatch(_ *Client, m Message) {
 	if c.message != nil && !strings.HasPrefix(m.Topic(), "emitter/") {
 		handlers := c.handlers.Lookup(m.Topic())
 		if len(handlers) == 0 { // Invoke the default message handler
@@ -157,6 +189,8 @@ func (c *Client) onMessage(_ mqtt.Client, m mqtt.Message) {
 		var response PresenceEvent
 		if err := json.Unmarshal(m.Payload(), &response); err == nil {
 			c.presence(c, response)
+		} else {
+			c.logger.Warnf("unable to unmarshal presence event: %v", err)
 		}
 
 	// Dispatch errors handler
@@ -180,7 +214,7 @@ func (c *Client) onMessage(_ mqtt.Client, m mqtt.Message) {
 }
 
 // OnResponse handles the incoming response for emitter messages.
-func (c *Client) onResponse(m mqtt.Message, resp Response) bool {
+func (c *Client) onResponse(m Message, resp Response) bool {
 
 	// Check if we've got an error response
 	var errResponse Error
@@ -192,18 +226,21 @@ func (c *Client) onResponse(m mqtt.Message, resp Response) bool {
 	if err := json.Unmarshal(m.Payload(), &resp); err == nil && resp.RequestID() > 0 {
 		return c.store.NotifyResponse(resp.RequestID(), resp)
 	}
+
+	c.logger.Debugf("unable to unmarshal response on %s", m.Topic())
 	return false
 }
 
 // OnError handles the incoming error.
-func (c *Client) onError(m mqtt.Message) {
+func (c *Client) onError(m Message) {
 	var resp Error
 	if err := json.Unmarshal(m.Payload(), &resp); err != nil {
+		c.logger.Warnf("unable to unmarshal error on %s: %v", m.Topic(), err)
 		return
 	}
 
 	if c.errors == nil {
-		log.Println("emitter:", resp.Error())
+		c.logger.Errorf("%s", resp.Error())
 	}
 
 	if c.errors != nil && !c.store.NotifyResponse(resp.RequestID(), &resp) {
@@ -218,6 +255,9 @@ func (c *Client) IsConnected() bool {
 
 // Connect initiates a connection to the broker.
 func (c *Client) Connect() error {
+	if c.configErr != nil {
+		return c.configErr
+	}
 	return c.do(c.conn.Connect())
 }
 
@@ -247,6 +287,9 @@ func (c *Client) Disconnect(waitTime time.Duration) {
 // Publish will publish a message with the specified QoS and content to the specified topic.
 // Returns a token to track delivery of the message to the broker
 func (c *Client) Publish(key string, channel string, payload interface{}, options ...Option) error {
+	atomic.AddInt32(&c.pending, 1)
+	defer atomic.AddInt32(&c.pending, -1)
+
 	token := c.conn.Publish(formatTopic(key, channel, options), 0, false, payload)
 	return c.do(token)
 }
@@ -268,6 +311,7 @@ func (c *Client) Subscribe(key string, channel string, optionalHandler MessageHa
 	if optionalHandler != nil {
 		c.handlers.AddHandler(channel, optionalHandler)
 	}
+	c.trackSubscription(key, channel, optionalHandler, options)
 
 	// Issue subscribe
 	token := c.conn.Subscribe(formatTopic(key, channel, options), 0, nil)
@@ -287,6 +331,7 @@ func (c *Client) Unsubscribe(key string, channel string) error {
 
 	// Remove the handler if we have one
 	c.handlers.RemoveHandler(channel)
+	c.untrackSubscription(channel)
 
 	// Issue the unsubscribe
 	token := c.conn.Unsubscribe(formatTopic(key, channel, nil))
@@ -345,6 +390,7 @@ func (c *Client) CreatePrivateLink(key, channel, name string, optionalHandler Me
 		if optionalHandler != nil {
 			c.handlers.AddHandler(result.Channel, optionalHandler)
 		}
+		c.trackLink(key, channel, name, true, optionalHandler)
 
 		return result, nil
 	}
@@ -371,6 +417,7 @@ func (c *Client) CreateLink(key, channel, name string, optionalHandler MessageHa
 		if optionalHandler != nil {
 			c.handlers.AddHandler(result.Channel, optionalHandler)
 		}
+		c.trackLink(key, channel, name, false, optionalHandler)
 
 		return result, nil
 	}
@@ -394,7 +441,7 @@ func (c *Client) request(operation string, req interface{}) (Response, error) {
 	if err, ok := resp.(error); ok {
 		return nil, err
 	}
-	return resp, nil
+	return resp.(Response), nil
 }
 
 // do waits for the operation to complete
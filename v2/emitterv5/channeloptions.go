@@ -0,0 +1,24 @@
+package emitterv5
+
+import "strconv"
+
+// ChannelOption is a channel query-string option, such as a TTL or history
+// depth, appended to the topic a Publish or Subscribe is issued against.
+type ChannelOption interface {
+	String() string
+}
+
+type channelOption string
+
+func (o channelOption) String() string { return string(o) }
+
+// WithTTL sets a Time-To-Live, in seconds, on a published message.
+func WithTTL(seconds int) ChannelOption {
+	return channelOption("ttl=" + strconv.Itoa(seconds))
+}
+
+// WithLast requests the specified number of retained messages be replayed
+// on subscribe.
+func WithLast(count int) ChannelOption {
+	return channelOption("last=" + strconv.Itoa(count))
+}
@@ -0,0 +1,518 @@
+// Package emitterv5 is a sibling of package emitter that speaks MQTT v5
+// (github.com/eclipse/paho.golang) instead of v3.1.1. It mirrors the v3.1.1
+// surface - Connect, Publish, Subscribe, GenerateKey, CreatePrivateLink,
+// Presence, OnMessage, and friends - while adding the v5-only capabilities
+// that make it worth the separate package: reason codes on publish and
+// subscribe results, user properties on received messages, correlation
+// data for request/response matching instead of the MessageID trick, and
+// client-assigned topic aliases for channels that are published frequently.
+package emitterv5
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eclipse/paho.golang/paho"
+)
+
+// Various emitter errors.
+var (
+	ErrTimeout   = errors.New("emitterv5: operation has timed out")
+	ErrUnmarshal = errors.New("emitterv5: unable to unmarshal the response")
+)
+
+// Client represents an emitter client which holds the v5 connection.
+type Client struct {
+	sync.Mutex
+	brokers   []string         // Broker URLs to connect to
+	clientID  string           // MQTT client ID
+	conn      *paho.Client     // MQTT v5 client
+	store     *store           // In-flight request correlation store
+	handlers  *trie            // The registry for per-channel handlers
+	aliases   *topicAliasCache // Client-assigned topic aliases for hot channels
+	timeout   time.Duration    // Default timeout
+	connected int32            // Atomic: 1 once Connect has succeeded, reset to 0 on disconnect
+	logger    Logger           // Destination for library-side diagnostics
+
+	guid            string            // Emitter's client ID, once known
+	message         MessageHandler    // User-defined message handler
+	connect         ConnectHandler    // User-defined connect handler
+	disconnect      DisconnectHandler // User-defined disconnect handler
+	presence        PresenceHandler   // User-defined presence handler
+	errors          ErrorHandler      // User-defined error handler
+	middlewareChain []Middleware      // Chain wrapped around every dispatched message
+}
+
+// Connect is a convenience function which sets a broker and connects to it.
+func Connect(host string, handler MessageHandler, options ...Option) (*Client, error) {
+	if len(host) > 0 {
+		options = append(options, WithBrokers(host))
+	}
+
+	client := NewClient(options...)
+	client.OnMessage(handler)
+
+	err := client.Connect()
+	return client, err
+}
+
+// NewClient will create an MQTT v5 client with all of the options specified.
+// The client must have the Connect method called on it before it may be
+// used, so that resources (such as a net connection) are created only once
+// the application is actually ready.
+func NewClient(options ...Option) *Client {
+	c := &Client{
+		clientID: newCorrelationID(),
+		store:    newStore(),
+		handlers: newTrie(),
+		aliases:  newTopicAliasCache(),
+		timeout:  60 * time.Second,
+		logger:   stdLogger{},
+	}
+
+	WithBrokers("tcp://api.emitter.io:8080")(c)
+
+	for _, opt := range options {
+		opt(c)
+	}
+
+	return c
+}
+
+// OnMessage sets the MessageHandler that will be called when a message is
+// received that does not match any known subscriptions.
+func (c *Client) OnMessage(handler MessageHandler) {
+	c.message = handler
+}
+
+// OnConnect sets the function to be called each time Connect succeeds in
+// connecting to a broker. The client does not reconnect on its own after
+// losing its connection.
+func (c *Client) OnConnect(handler ConnectHandler) {
+	c.connect = handler
+}
+
+// OnDisconnect will set the function callback to be executed in the case
+// where the client unexpectedly loses connection with the MQTT broker.
+func (c *Client) OnDisconnect(handler DisconnectHandler) {
+	c.disconnect = handler
+}
+
+// OnPresence sets the function that will be called when a presence event is
+// received.
+func (c *Client) OnPresence(handler PresenceHandler) {
+	c.presence = handler
+}
+
+// OnError will set the function callback to be executed if an
+// emitter-specific error occurs.
+func (c *Client) OnError(handler ErrorHandler) {
+	c.errors = handler
+}
+
+// IsConnected returns a bool signifying whether the client is connected or
+// not. paho.Client exposes no such query itself, so this tracks state
+// locally: set once Connect succeeds, cleared on OnClientError or an
+// explicit Disconnect.
+func (c *Client) IsConnected() bool {
+	return c.conn != nil && atomic.LoadInt32(&c.connected) == 1
+}
+
+// Connect initiates a connection, trying each broker passed to WithBrokers
+// in order until one succeeds.
+func (c *Client) Connect() error {
+	if len(c.brokers) == 0 {
+		return errors.New("emitterv5: no brokers configured")
+	}
+
+	var errs []error
+	for _, broker := range c.brokers {
+		if err := c.connectTo(broker); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", broker, err))
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("emitterv5: unable to connect to any broker: %w", errors.Join(errs...))
+}
+
+// connectTo dials and connects to a single broker, installing it as the
+// active connection only once the CONNACK confirms success.
+func (c *Client) connectTo(broker string) error {
+	conn, err := dial(context.Background(), broker)
+	if err != nil {
+		return err
+	}
+
+	pahoClient := paho.NewClient(paho.ClientConfig{
+		Conn:   conn,
+		Router: paho.NewSingleHandlerRouter(c.onMessage),
+		OnClientError: func(err error) {
+			atomic.StoreInt32(&c.connected, 0)
+			if c.disconnect != nil {
+				c.disconnect(c, err)
+			}
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	ca, err := pahoClient.Connect(ctx, &paho.Connect{
+		ClientID:   c.clientID,
+		CleanStart: true,
+		KeepAlive:  30,
+	})
+	if err != nil {
+		return err
+	}
+	if ca.ReasonCode != 0 {
+		return fmt.Errorf("connect refused, reason code %d", ca.ReasonCode)
+	}
+
+	c.conn = pahoClient
+	if ca.Properties != nil {
+		c.aliases.setServerMax(ca.Properties.TopicAliasMaximum)
+	}
+	atomic.StoreInt32(&c.connected, 1)
+
+	if c.connect != nil {
+		c.connect(c)
+	}
+	return nil
+}
+
+// onMessage occurs when the MQTT v5 client receives a PUBLISH packet. It
+// runs the message through the middleware chain installed via Use before
+// dispatching it, so middleware such as metrics, tracing or panic recovery
+// see every message the client receives exactly once.
+func (c *Client) onMessage(pub *paho.Publish) {
+	c.chain()(c, &message{pub: pub})
+}
+
+// dispatch routes an incoming message to the appropriate handler.
+func (c *Client) dispatch(_ *Client, msg Message) {
+	pub := msg.(*message).pub
+	m := msg
+
+	if c.message != nil && !strings.HasPrefix(pub.Topic, "emitter/") {
+		handlers := c.handlers.Lookup(pub.Topic)
+		if len(handlers) == 0 {
+			c.message(c, m)
+		}
+		for _, h := range handlers {
+			h(c, m)
+		}
+		return
+	}
+
+	switch {
+	case c.presence != nil && strings.HasPrefix(pub.Topic, "emitter/presence/"):
+		var resp PresenceEvent
+		if err := json.Unmarshal(pub.Payload, &resp); err == nil {
+			c.presence(c, resp)
+		}
+
+	case strings.HasPrefix(pub.Topic, "emitter/error/"):
+		c.onError(pub)
+
+	case strings.HasPrefix(pub.Topic, "emitter/keygen/"):
+		c.onResponse(pub, new(keyGenResponse))
+
+	case strings.HasPrefix(pub.Topic, "emitter/link/"):
+		c.onResponse(pub, new(Link))
+
+	case strings.HasPrefix(pub.Topic, "emitter/me/"):
+		c.onResponse(pub, new(meResponse))
+	}
+}
+
+// onResponse handles the incoming response for emitter request/response
+// messages, matching it to the caller waiting on it by correlation data.
+func (c *Client) onResponse(pub *paho.Publish, resp Response) bool {
+	var errResponse Error
+	if err := json.Unmarshal(pub.Payload, &errResponse); err == nil && errResponse.Error() != "" {
+		return c.store.NotifyResponse(correlationID(pub), &errResponse)
+	}
+
+	if err := json.Unmarshal(pub.Payload, &resp); err == nil && resp.RequestID() != "" {
+		return c.store.NotifyResponse(resp.RequestID(), resp)
+	}
+	return c.store.NotifyResponse(correlationID(pub), resp)
+}
+
+// onError handles an incoming emitter-specific error.
+func (c *Client) onError(pub *paho.Publish) {
+	var resp Error
+	if err := json.Unmarshal(pub.Payload, &resp); err != nil {
+		return
+	}
+
+	if c.errors != nil && !c.store.NotifyResponse(correlationID(pub), &resp) {
+		c.errors(c, resp)
+	}
+}
+
+// correlationID extracts the v5 correlation data from a publish, falling
+// back to the topic itself so unrelated responses don't collide.
+func correlationID(pub *paho.Publish) string {
+	if pub.Properties != nil && len(pub.Properties.CorrelationData) > 0 {
+		return string(pub.Properties.CorrelationData)
+	}
+	return pub.Topic
+}
+
+// Disconnect will end the connection with the server, but not before
+// waiting the specified duration for existing work to be completed.
+func (c *Client) Disconnect(waitTime time.Duration) {
+	atomic.StoreInt32(&c.connected, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), waitTime)
+	defer cancel()
+	<-ctx.Done()
+	_ = c.conn.Disconnect(&paho.Disconnect{ReasonCode: 0})
+}
+
+// PublishResult carries the outcome of a Publish call, including the
+// reason code the broker returned (v5-only; v3.1.1 PUBACK has none).
+type PublishResult struct {
+	ReasonCode byte
+}
+
+// Publish will publish a message at QoS 0 with the given content to the
+// specified channel, returning the broker's reason code. When the broker
+// advertised a TopicAliasMaximum, a channel is given a client-assigned
+// topic alias on its first publish, and later publishes to it send only
+// the alias instead of the full topic string.
+func (c *Client) Publish(key, channel string, payload []byte, options ...ChannelOption) (PublishResult, error) {
+	topic := formatTopic(key, channel, options)
+	props := &paho.PublishProperties{}
+
+	if alias, topicToSend := c.aliases.use(topic); alias != 0 {
+		props.TopicAlias = &alias
+		topic = topicToSend
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	pr, err := c.conn.Publish(ctx, &paho.Publish{
+		Topic:      topic,
+		Payload:    payload,
+		Properties: props,
+	})
+	if err != nil {
+		return PublishResult{}, err
+	}
+	return PublishResult{ReasonCode: pr.ReasonCode}, nil
+}
+
+// PublishWithTTL publishes a message with a specified Time-To-Live option.
+func (c *Client) PublishWithTTL(key, channel string, payload []byte, ttl int) (PublishResult, error) {
+	return c.Publish(key, channel, payload, WithTTL(ttl))
+}
+
+// Subscribe starts a new subscription. Provide a MessageHandler to be
+// executed when a message is published on the channel provided. The
+// returned reason code reflects the maximum QoS (or failure) the broker
+// granted.
+func (c *Client) Subscribe(key, channel string, optionalHandler MessageHandler, options ...ChannelOption) (byte, error) {
+	if optionalHandler != nil {
+		c.handlers.AddHandler(channel, optionalHandler)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	sa, err := c.conn.Subscribe(ctx, &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{
+			{Topic: formatTopic(key, channel, options), QoS: 0},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(sa.Reasons) == 0 {
+		return 0, nil
+	}
+	return sa.Reasons[0], nil
+}
+
+// SubscribeWithHistory performs a subscribe with an option to retrieve the
+// specified number of messages already published in the channel.
+func (c *Client) SubscribeWithHistory(key, channel string, last int, optionalHandler MessageHandler) (byte, error) {
+	return c.Subscribe(key, channel, optionalHandler, WithLast(last))
+}
+
+// Unsubscribe will end the subscription from the channel provided.
+func (c *Client) Unsubscribe(key, channel string) error {
+	c.handlers.RemoveHandler(channel)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	_, err := c.conn.Unsubscribe(ctx, &paho.Unsubscribe{
+		Topics: []string{formatTopic(key, channel, nil)},
+	})
+	return err
+}
+
+// Presence sends a presence request to the broker.
+func (c *Client) Presence(key, channel string, status, changes bool) error {
+	req, err := json.Marshal(&presenceRequest{
+		Key:     key,
+		Channel: channel,
+		Status:  status,
+		Changes: changes,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	_, err = c.conn.Publish(ctx, &paho.Publish{Topic: "emitter/presence/", QoS: 1, Payload: req})
+	return err
+}
+
+// ID retrieves the client's own GUID, querying the broker the first time
+// it's called.
+func (c *Client) ID() string {
+	if c.guid != "" {
+		return c.guid
+	}
+
+	if resp, err := c.request("me", nil); err == nil {
+		if result, ok := resp.(*meResponse); ok {
+			c.guid = result.Guid
+		}
+	}
+	return c.guid
+}
+
+// GenerateKey sends a key generation request to the broker.
+func (c *Client) GenerateKey(key, channel, permissions string, ttl int) (string, error) {
+	resp, err := c.request("keygen", &keygenRequest{
+		Key:     key,
+		Channel: channel,
+		Type:    permissions,
+		TTL:     ttl,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if result, ok := resp.(*keyGenResponse); ok {
+		return result.Key, nil
+	}
+	return "", ErrUnmarshal
+}
+
+// CreatePrivateLink sends a request to create a private link.
+func (c *Client) CreatePrivateLink(key, channel, name string, optionalHandler MessageHandler, options ...ChannelOption) (*Link, error) {
+	return c.createLink(key, channel, name, true, optionalHandler, options)
+}
+
+// CreateLink sends a request to create a default link.
+func (c *Client) CreateLink(key, channel, name string, optionalHandler MessageHandler, options ...ChannelOption) (*Link, error) {
+	return c.createLink(key, channel, name, false, optionalHandler, options)
+}
+
+func (c *Client) createLink(key, channel, name string, private bool, optionalHandler MessageHandler, options []ChannelOption) (*Link, error) {
+	resp, err := c.request("link", &linkRequest{
+		Name:      name,
+		Key:       key,
+		Channel:   formatTopic("", channel, options),
+		Subscribe: optionalHandler != nil,
+		Private:   private,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := resp.(*Link)
+	if !ok {
+		return nil, ErrUnmarshal
+	}
+	if optionalHandler != nil {
+		c.handlers.AddHandler(result.Channel, optionalHandler)
+	}
+	return result, nil
+}
+
+// request makes a keygen/link/me request and waits for the correlated
+// response, using v5 correlation data instead of the v3.1.1 MessageID.
+func (c *Client) request(operation string, req interface{}) (Response, error) {
+	id := newCorrelationID()
+
+	if r, ok := req.(requestWithID); ok {
+		r.setRequestID(id)
+	}
+
+	var payload []byte
+	var err error
+	if req != nil {
+		payload, err = json.Marshal(req)
+		if err != nil {
+			panic("emitterv5: unable to encode the request")
+		}
+	}
+
+	respCh := c.store.PutCallback(id)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	_, err = c.conn.Publish(ctx, &paho.Publish{
+		Topic:   fmt.Sprintf("emitter/%s/", operation),
+		QoS:     1,
+		Payload: payload,
+		Properties: &paho.PublishProperties{
+			CorrelationData: []byte(id),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case resp := <-respCh:
+		if e, ok := resp.(error); ok {
+			return nil, e
+		}
+		return resp.(Response), nil
+	case <-time.After(c.timeout):
+		return nil, ErrTimeout
+	}
+}
+
+// formatTopic makes a topic name from the key/channel pair, identical in
+// shape to the v3.1.1 client's formatTopic.
+func formatTopic(key, channel string, options []ChannelOption) string {
+	key = strings.TrimPrefix(key, "/")
+	key = strings.TrimSuffix(key, "/")
+
+	channel = strings.TrimPrefix(channel, "/")
+	channel = strings.TrimSuffix(channel, "/")
+
+	opts := ""
+	if len(options) > 0 {
+		opts += "?"
+		for i, option := range options {
+			opts += option.String()
+			if i+1 < len(options) {
+				opts += "&"
+			}
+		}
+	}
+
+	if len(key) == 0 {
+		return fmt.Sprintf("%s/%s", channel, opts)
+	}
+	return fmt.Sprintf("%s/%s/%s", key, channel, opts)
+}
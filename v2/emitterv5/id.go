@@ -0,0 +1,17 @@
+package emitterv5
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// newCorrelationID returns a random, URL-safe identifier used both as the
+// MQTT v5 client ID (when none is configured) and as PUBLISH correlation
+// data for request/response matching.
+func newCorrelationID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
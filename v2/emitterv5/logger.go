@@ -0,0 +1,29 @@
+package emitterv5
+
+import "log"
+
+// Logger is implemented by any structured logging library (zap, logrus,
+// slog, ...) that callers want the client's internal diagnostics routed
+// through, instead of the package-level log.Println calls used by default.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// WithLogger installs logger as the destination for every library-side log
+// message, including a recovered handler panic, which is logged even when
+// no ErrorHandler is set.
+func WithLogger(logger Logger) Option {
+	return func(c *Client) { c.logger = logger }
+}
+
+// stdLogger is the default Logger, mirroring package emitter's historical
+// behavior of writing to the stdlib log package.
+type stdLogger struct{}
+
+func (stdLogger) Debugf(format string, args ...interface{}) { log.Printf("emitterv5: "+format, args...) }
+func (stdLogger) Infof(format string, args ...interface{})  { log.Printf("emitterv5: "+format, args...) }
+func (stdLogger) Warnf(format string, args ...interface{})  { log.Printf("emitterv5: "+format, args...) }
+func (stdLogger) Errorf(format string, args ...interface{}) { log.Printf("emitterv5: "+format, args...) }
@@ -0,0 +1,50 @@
+package emitterv5
+
+// Middleware wraps a MessageHandler with additional behavior, the same way
+// net/http middleware wraps a Handler. Middleware installed via Client.Use
+// sees every message the client receives: the default handler set by
+// OnMessage, every per-channel handler registered via Subscribe or
+// CreateLink/CreatePrivateLink, and the client's internal dispatch for
+// presence/error/keygen/link/me control messages.
+type Middleware func(next MessageHandler) MessageHandler
+
+// Use installs middleware, in the order given, around every message the
+// client dispatches. The first middleware passed runs outermost. Use must
+// be called before Connect: the chain is rebuilt for every incoming
+// message, and Use does not synchronise with that.
+func (c *Client) Use(mw ...Middleware) {
+	c.middlewareChain = append(c.middlewareChain, mw...)
+}
+
+// chain builds the MessageHandler that onMessage invokes for every
+// incoming PUBLISH.
+func (c *Client) chain() MessageHandler {
+	h := MessageHandler(c.dispatch)
+	for i := len(c.middlewareChain) - 1; i >= 0; i-- {
+		h = c.middlewareChain[i](h)
+	}
+	return h
+}
+
+// RecoveryMiddleware returns a Middleware that recovers from a panic in
+// any handler further down the chain and reports it via the client's
+// ErrorHandler instead of crashing the paho dispatch goroutine. The panic
+// is always logged via the client's Logger, even when no ErrorHandler is
+// set, so it's never swallowed with zero observability.
+func RecoveryMiddleware() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(c *Client, m Message) {
+			defer func() {
+				if r := recover(); r != nil {
+					if c.logger != nil {
+						c.logger.Errorf("handler panic on %s: %v", m.Topic(), r)
+					}
+					if c.errors != nil {
+						c.errors(c, Error{Message: "emitterv5: handler panic on " + m.Topic()})
+					}
+				}
+			}()
+			next(c, m)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package emitterv5
+
+import (
+	"testing"
+
+	"github.com/eclipse/paho.golang/paho"
+)
+
+func testMessage(topic string) Message {
+	return &message{pub: &paho.Publish{Topic: topic}}
+}
+
+func TestChainOrdering(t *testing.T) {
+	c := &Client{handlers: newTrie(), logger: stdLogger{}}
+
+	var order []string
+	mark := func(name string) Middleware {
+		return func(next MessageHandler) MessageHandler {
+			return func(c *Client, m Message) {
+				order = append(order, name)
+				next(c, m)
+			}
+		}
+	}
+	c.Use(mark("outer"), mark("inner"))
+
+	var dispatched bool
+	c.message = func(*Client, Message) { dispatched = true }
+
+	c.chain()(c, testMessage("a/b/c"))
+
+	if want := []string{"outer", "inner"}; len(order) != len(want) || order[0] != want[0] || order[1] != want[1] {
+		t.Fatalf("middleware ran in order %v, want %v", order, want)
+	}
+	if !dispatched {
+		t.Error("expected the chain to reach the message handler")
+	}
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	c := &Client{handlers: newTrie(), logger: stdLogger{}}
+	c.Use(RecoveryMiddleware())
+
+	var reported Error
+	c.errors = func(_ *Client, e Error) { reported = e }
+	c.message = func(*Client, Message) { panic("boom") }
+
+	c.chain()(c, testMessage("a/b/c"))
+
+	if reported.Message == "" {
+		t.Error("expected the recovered panic to be reported via ErrorHandler")
+	}
+}
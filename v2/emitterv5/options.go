@@ -0,0 +1,36 @@
+package emitterv5
+
+import (
+	"time"
+
+	"github.com/gmlewis/emitter-io-go/v2/internal/clientopts"
+)
+
+// Option configures a Client during NewClient.
+type Option func(*Client)
+
+var _ clientopts.Target = (*Client)(nil)
+
+// SetBrokers implements clientopts.Target so that WithBrokers can be shared
+// between this package and the v3.1.1 client in package emitter.
+func (c *Client) SetBrokers(urls []string) {
+	c.brokers = urls
+}
+
+// WithBrokers sets one or more MQTT v5 broker URLs (tcp://, ssl://, ws://,
+// wss://) that the client will attempt to connect to.
+func WithBrokers(urls ...string) Option {
+	shared := clientopts.WithBrokers(urls...)
+	return func(c *Client) { shared(c) }
+}
+
+// WithClientID overrides the randomly generated MQTT client ID.
+func WithClientID(id string) Option {
+	return func(c *Client) { c.clientID = id }
+}
+
+// WithTimeout overrides the default 60 second timeout used while waiting
+// for keygen/link/me responses and publish/subscribe acknowledgements.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) { c.timeout = timeout }
+}
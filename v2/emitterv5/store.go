@@ -0,0 +1,48 @@
+package emitterv5
+
+import "sync"
+
+// store tracks in-flight keygen/link/me requests so that the response
+// carrying a matching correlation ID can be routed back to the caller that
+// is waiting on it. Unlike the v3.1.1 client, which matches on the
+// underlying MQTT MessageID, the v5 client matches on the PUBLISH
+// correlation data it attaches to the request, which survives QoS
+// retransmission and doesn't collide across reconnects.
+type store struct {
+	sync.Mutex
+	pending map[string]chan interface{}
+}
+
+func newStore() *store {
+	return &store{pending: make(map[string]chan interface{})}
+}
+
+// PutCallback registers a channel that will receive the response (or error)
+// correlated with id.
+func (s *store) PutCallback(id string) chan interface{} {
+	ch := make(chan interface{}, 1)
+
+	s.Lock()
+	s.pending[id] = ch
+	s.Unlock()
+
+	return ch
+}
+
+// NotifyResponse delivers resp to the callback waiting on id, if any, and
+// reports whether one was found.
+func (s *store) NotifyResponse(id string, resp interface{}) bool {
+	s.Lock()
+	ch, ok := s.pending[id]
+	if ok {
+		delete(s.pending, id)
+	}
+	s.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	ch <- resp
+	return true
+}
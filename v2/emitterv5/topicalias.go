@@ -0,0 +1,56 @@
+package emitterv5
+
+import "sync"
+
+// topicAliasCache assigns client-side MQTT v5 topic aliases to channels on
+// their first publish, so that "hot" channels can be republished using a
+// 2-byte alias instead of the full topic string, up to the maximum the
+// broker advertised in its CONNACK.
+type topicAliasCache struct {
+	sync.Mutex
+	serverMax uint16
+	next      uint16
+	aliases   map[string]uint16
+}
+
+func newTopicAliasCache() *topicAliasCache {
+	return &topicAliasCache{aliases: make(map[string]uint16)}
+}
+
+// setServerMax records the broker's advertised TopicAliasMaximum, which
+// paho reports as a *uint16 that is nil when the broker didn't send the
+// property at all (meaning it doesn't support topic aliasing).
+func (c *topicAliasCache) setServerMax(max *uint16) {
+	c.Lock()
+	defer c.Unlock()
+	if max == nil {
+		c.serverMax = 0
+		return
+	}
+	c.serverMax = *max
+}
+
+// use returns the alias to publish with (0 if aliasing isn't available) and
+// the topic string that should actually be sent: the full topic the first
+// time an alias is assigned, or "" on every subsequent publish once the
+// broker already knows the mapping.
+func (c *topicAliasCache) use(topic string) (alias uint16, topicToSend string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.serverMax == 0 {
+		return 0, topic
+	}
+
+	if a, ok := c.aliases[topic]; ok {
+		return a, ""
+	}
+
+	if c.next >= c.serverMax {
+		return 0, topic
+	}
+
+	c.next++
+	c.aliases[topic] = c.next
+	return c.next, topic
+}
@@ -0,0 +1,49 @@
+package emitterv5
+
+import "testing"
+
+func TestTopicAliasCacheNoServerSupport(t *testing.T) {
+	c := newTopicAliasCache()
+
+	alias, topic := c.use("a/b/c")
+	if alias != 0 || topic != "a/b/c" {
+		t.Errorf("use() = (%d, %q), want (0, %q) when the broker advertised no TopicAliasMaximum", alias, topic, "a/b/c")
+	}
+}
+
+func TestTopicAliasCacheAssignsAndReusesAliases(t *testing.T) {
+	c := newTopicAliasCache()
+	max := uint16(2)
+	c.setServerMax(&max)
+
+	alias, topic := c.use("a/b/c")
+	if alias != 1 || topic != "a/b/c" {
+		t.Fatalf("first use() = (%d, %q), want (1, %q)", alias, topic, "a/b/c")
+	}
+
+	alias, topic = c.use("a/b/c")
+	if alias != 1 || topic != "" {
+		t.Fatalf("second use() = (%d, %q), want (1, \"\") once the broker knows the mapping", alias, topic)
+	}
+
+	alias, topic = c.use("d/e/f")
+	if alias != 2 || topic != "d/e/f" {
+		t.Fatalf("use() for a second channel = (%d, %q), want (2, %q)", alias, topic, "d/e/f")
+	}
+
+	alias, topic = c.use("g/h/i")
+	if alias != 0 || topic != "g/h/i" {
+		t.Fatalf("use() past serverMax = (%d, %q), want (0, %q)", alias, topic, "g/h/i")
+	}
+}
+
+func TestTopicAliasCacheSetServerMaxNil(t *testing.T) {
+	c := newTopicAliasCache()
+	max := uint16(5)
+	c.setServerMax(&max)
+	c.setServerMax(nil)
+
+	if alias, topic := c.use("a/b/c"); alias != 0 || topic != "a/b/c" {
+		t.Errorf("use() after setServerMax(nil) = (%d, %q), want (0, %q)", alias, topic, "a/b/c")
+	}
+}
@@ -0,0 +1,98 @@
+package emitterv5
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dial opens the underlying net.Conn for a broker URL, honoring the
+// tcp://, ssl://, ws:// and wss:// schemes emitter.io brokers use.
+func dial(ctx context.Context, broker string) (net.Conn, error) {
+	u, err := url.Parse(broker)
+	if err != nil {
+		return nil, fmt.Errorf("emitterv5: invalid broker url %q: %w", broker, err)
+	}
+
+	switch u.Scheme {
+	case "tcp", "":
+		var d net.Dialer
+		return d.DialContext(ctx, "tcp", u.Host)
+
+	case "ssl", "tls":
+		var d tls.Dialer
+		return d.DialContext(ctx, "tcp", u.Host)
+
+	case "ws", "wss":
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, broker, nil)
+		if err != nil {
+			return nil, err
+		}
+		return newWsConn(conn), nil
+
+	default:
+		return nil, fmt.Errorf("emitterv5: unsupported broker scheme %q", u.Scheme)
+	}
+}
+
+// wsConn adapts a gorilla/websocket connection to the net.Conn interface
+// paho's transport expects, reading and writing MQTT packets as binary
+// websocket messages. gorilla/websocket has no built-in net.Conn adapter
+// (unlike nhooyr.io/websocket's NetConn), so Read buffers across websocket
+// message boundaries itself.
+type wsConn struct {
+	*websocket.Conn
+	reader io.Reader
+}
+
+func newWsConn(conn *websocket.Conn) *wsConn {
+	return &wsConn{Conn: conn}
+}
+
+// Read implements net.Conn, pulling from the current websocket message
+// until it's exhausted and then waiting for the next one.
+func (c *wsConn) Read(b []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			_, r, err := c.Conn.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			c.reader = r
+		}
+
+		n, err := c.reader.Read(b)
+		if err == io.EOF {
+			c.reader = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Write implements net.Conn, sending b as a single binary websocket
+// message.
+func (c *wsConn) Write(b []byte) (int, error) {
+	if err := c.Conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// SetDeadline implements net.Conn; gorilla/websocket has no combined
+// deadline setter, so apply it to both directions.
+func (c *wsConn) SetDeadline(t time.Time) error {
+	if err := c.Conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.Conn.SetWriteDeadline(t)
+}
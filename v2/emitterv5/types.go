@@ -0,0 +1,165 @@
+package emitterv5
+
+import "github.com/eclipse/paho.golang/paho"
+
+// Message defines the externals that a message implementation must support.
+// These are received messages that are passed to the callbacks, not internal
+// messages. Unlike the v3.1.1 Message, a v5 Message also carries any user
+// properties the publisher attached. A received PUBLISH has no reason code
+// of its own to report - only the PublishResult and Subscribe's return
+// value carry one, for the broker's handling of that specific call.
+type Message interface {
+	Topic() string
+	Payload() []byte
+	UserProperties() map[string]string
+}
+
+// message adapts a paho v5 publish packet to the Message interface.
+type message struct {
+	pub *paho.Publish
+}
+
+func (m *message) Topic() string   { return m.pub.Topic }
+func (m *message) Payload() []byte { return m.pub.Payload }
+
+func (m *message) UserProperties() map[string]string {
+	out := make(map[string]string, len(m.pub.Properties.User))
+	for _, p := range m.pub.Properties.User {
+		out[p.Key] = p.Value
+	}
+	return out
+}
+
+// Response is implemented by every emitter response payload that carries a
+// request/response correlation ID, used to match a PUBLISH reply to the
+// request that triggered it.
+type Response interface {
+	RequestID() string
+}
+
+// requestWithID is implemented by every request payload that carries a req
+// correlation field, so that request() can stamp the correlation ID it
+// generates into the outgoing payload before marshaling it. Without this,
+// the broker has no way to echo the ID back in its reply.
+type requestWithID interface {
+	setRequestID(id string)
+}
+
+// Error represents an emitter-specific error response.
+type Error struct {
+	ID      string `json:"req,omitempty"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// RequestID implements Response.
+func (e *Error) RequestID() string { return e.ID }
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return ""
+	}
+	return e.Message
+}
+
+// keygenRequest requests a new channel key.
+type keygenRequest struct {
+	ID      string `json:"req,omitempty"`
+	Key     string `json:"key"`
+	Channel string `json:"channel"`
+	Type    string `json:"type"`
+	TTL     int    `json:"ttl"`
+}
+
+// keyGenResponse is the broker's reply to a keygenRequest.
+type keyGenResponse struct {
+	ID      string `json:"req,omitempty"`
+	Status  int    `json:"status"`
+	Key     string `json:"key"`
+	Channel string `json:"channel"`
+}
+
+// RequestID implements Response.
+func (r *keyGenResponse) RequestID() string { return r.ID }
+
+// setRequestID implements requestWithID.
+func (r *keygenRequest) setRequestID(id string) { r.ID = id }
+
+// presenceRequest requests presence information on a channel.
+type presenceRequest struct {
+	ID      string `json:"req,omitempty"`
+	Key     string `json:"key"`
+	Channel string `json:"channel"`
+	Status  bool   `json:"status"`
+	Changes bool   `json:"changes"`
+}
+
+// setRequestID implements requestWithID.
+func (r *presenceRequest) setRequestID(id string) { r.ID = id }
+
+// PresenceEvent represents a presence event received from the broker.
+type PresenceEvent struct {
+	Time    int64          `json:"time"`
+	Event   string         `json:"event"`
+	Channel string         `json:"channel"`
+	Who     []PresenceInfo `json:"who"`
+}
+
+// PresenceInfo describes a single subscriber in a PresenceEvent.
+type PresenceInfo struct {
+	ID       string `json:"id"`
+	Username string `json:"username,omitempty"`
+}
+
+// linkRequest requests creation of a private link.
+type linkRequest struct {
+	ID        string `json:"req,omitempty"`
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+	Channel   string `json:"channel"`
+	Subscribe bool   `json:"subscribe"`
+	Private   bool   `json:"private"`
+}
+
+// Link represents an emitter private link.
+type Link struct {
+	ID      string `json:"req,omitempty"`
+	Status  int    `json:"status"`
+	Name    string `json:"name"`
+	Channel string `json:"channel"`
+}
+
+// RequestID implements Response.
+func (l *Link) RequestID() string { return l.ID }
+
+// setRequestID implements requestWithID.
+func (r *linkRequest) setRequestID(id string) { r.ID = id }
+
+// meResponse carries the client's own identity, as returned by "emitter/me/".
+type meResponse struct {
+	ID    string   `json:"req,omitempty"`
+	Links []string `json:"links,omitempty"`
+	Guid  string   `json:"id"`
+}
+
+// RequestID implements Response.
+func (r *meResponse) RequestID() string { return r.ID }
+
+// MessageHandler is an event handler function for incoming messages.
+type MessageHandler func(*Client, Message)
+
+// ConnectHandler is called each time Connect successfully connects to a
+// broker; the client does not reconnect on its own.
+type ConnectHandler func(*Client)
+
+// DisconnectHandler is called when the client loses connection with the
+// broker.
+type DisconnectHandler func(*Client, error)
+
+// PresenceHandler is called when a presence event is received.
+type PresenceHandler func(*Client, PresenceEvent)
+
+// ErrorHandler is called when an emitter-specific error is received that
+// isn't otherwise correlated to a pending request.
+type ErrorHandler func(*Client, Error)
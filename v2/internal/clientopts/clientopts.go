@@ -0,0 +1,19 @@
+// Package clientopts holds configuration surface shared between the
+// MQTT v3.1.1 client (package emitter) and the MQTT v5 client (package
+// emitterv5), so that option constructors which behave identically on
+// both transports don't need to be duplicated.
+package clientopts
+
+// Target is implemented by any emitter client whose broker list can be
+// configured via the shared With* option constructors.
+type Target interface {
+	SetBrokers(urls []string)
+}
+
+// WithBrokers returns an option usable by any Target implementation that
+// sets the broker URLs the client will attempt to connect to. tcp://,
+// ssl://, ws:// and wss:// schemes are all accepted; the scheme selects the
+// transport (plain TCP, TLS, or websocket) at dial time.
+func WithBrokers(urls ...string) func(Target) {
+	return func(t Target) { t.SetBrokers(urls) }
+}
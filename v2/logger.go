@@ -0,0 +1,52 @@
+package emitter
+
+import (
+	"fmt"
+	"log"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Logger is implemented by any structured logging library (zap, logrus,
+// slog, ...) that callers want the client's internal diagnostics routed
+// through, instead of the package-level log.Println calls used by default.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Infof(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// WithLogger installs logger as the destination for every library-side log
+// message: connection lost, unhandled emitter errors, and message
+// unmarshal failures that would otherwise be swallowed silently. It also
+// wires the paho package-level DEBUG/WARN/ERROR/CRITICAL loggers to logger,
+// so the full MQTT-layer trace can be captured alongside emitter's own.
+func WithLogger(logger Logger) func(*Client) {
+	return func(c *Client) {
+		c.logger = logger
+
+		mqtt.DEBUG = logAdapter{logger.Debugf}
+		mqtt.WARN = logAdapter{logger.Warnf}
+		mqtt.ERROR = logAdapter{logger.Errorf}
+		mqtt.CRITICAL = logAdapter{logger.Errorf}
+	}
+}
+
+// logAdapter adapts a Logger formatting method to paho's mqtt.Logger
+// interface, which takes Println/Printf rather than a single Xf method.
+type logAdapter struct {
+	logf func(format string, args ...interface{})
+}
+
+func (a logAdapter) Println(v ...interface{}) { a.logf("%s", fmt.Sprintln(v...)) }
+func (a logAdapter) Printf(format string, v ...interface{}) { a.logf(format, v...) }
+
+// stdLogger is the default Logger, preserving this package's historical
+// behavior of writing to the stdlib log package.
+type stdLogger struct{}
+
+func (stdLogger) Debugf(format string, args ...interface{}) { log.Printf("emitter: "+format, args...) }
+func (stdLogger) Infof(format string, args ...interface{})  { log.Printf("emitter: "+format, args...) }
+func (stdLogger) Warnf(format string, args ...interface{})  { log.Printf("emitter: "+format, args...) }
+func (stdLogger) Errorf(format string, args ...interface{}) { log.Printf("emitter: "+format, args...) }
@@ -0,0 +1,49 @@
+package emitter
+
+// Middleware wraps a MessageHandler with additional behavior, the same way
+// net/http middleware wraps a Handler. Middleware installed via Client.Use
+// sees every message the client receives: the default handler set by
+// OnMessage, every per-channel handler registered via Subscribe,
+// CreateLink or CreatePrivateLink, and emitter's own internal dispatch for
+// presence/error/keygen/link/me control messages.
+type Middleware func(next MessageHandler) MessageHandler
+
+// Use installs middleware, in the order given, around every message the
+// client dispatches. The first middleware passed runs outermost, so it
+// sees a message before (and a response after) any middleware that
+// follows it. Use must be called before Connect: the chain is rebuilt
+// for every incoming message, and Use does not synchronise with that.
+func (c *Client) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+}
+
+// chain builds the MessageHandler that onMessage invokes for every
+// incoming PUBLISH: dispatch wrapped by every installed middleware, with
+// the first middleware passed to Use ending up outermost.
+func (c *Client) chain() MessageHandler {
+	h := MessageHandler(c.dispatch)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h
+}
+
+// RecoveryMiddleware returns a Middleware that recovers from a panic in
+// any handler further down the chain, logs it through the client's
+// Logger, and reports it via the client's ErrorHandler instead of
+// crashing the paho dispatch goroutine.
+func RecoveryMiddleware() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(c *Client, m Message) {
+			defer func() {
+				if r := recover(); r != nil {
+					c.logger.Errorf("recovered from panic handling message on %s: %v", m.Topic(), r)
+					if c.errors != nil {
+						c.errors(c, Error{Message: "emitter: handler panic on " + m.Topic()})
+					}
+				}
+			}()
+			next(c, m)
+		}
+	}
+}
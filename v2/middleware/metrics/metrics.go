@@ -0,0 +1,81 @@
+// Package metrics provides a Prometheus-backed emitter.Middleware that
+// counts messages and payload bytes received, and optionally observes
+// per-channel delivery latency.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	emitter "github.com/gmlewis/emitter-io-go/v2"
+)
+
+// Options configures New. A nil ExtractTimestamp disables the latency
+// histogram, since most channels don't carry a timestamp a client can
+// compare itself against.
+type Options struct {
+	// Registerer is used to register the middleware's collectors. Defaults
+	// to prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+
+	// ExtractTimestamp, if set, extracts the time a message was produced
+	// from a header embedded in its payload (e.g. a leading JSON field),
+	// so latency can be computed against time.Now() on arrival.
+	ExtractTimestamp func(emitter.Message) (time.Time, bool)
+}
+
+// Middleware is the collector set and configuration behind New.
+type Middleware struct {
+	opts     Options
+	received *prometheus.CounterVec
+	bytes    *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// New registers the middleware's collectors and returns the
+// emitter.Middleware to install via Client.Use.
+func New(opts Options) (*Middleware, emitter.Middleware) {
+	if opts.Registerer == nil {
+		opts.Registerer = prometheus.DefaultRegisterer
+	}
+
+	m := &Middleware{
+		opts: opts,
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "emitter",
+			Name:      "messages_received_total",
+			Help:      "Number of messages received, by channel.",
+		}, []string{"channel"}),
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "emitter",
+			Name:      "message_bytes_received_total",
+			Help:      "Number of payload bytes received, by channel.",
+		}, []string{"channel"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "emitter",
+			Name:      "message_latency_seconds",
+			Help:      "Time between a message's embedded timestamp and its arrival, by channel.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"channel"}),
+	}
+
+	opts.Registerer.MustRegister(m.received, m.bytes, m.latency)
+	return m, m.middleware
+}
+
+func (m *Middleware) middleware(next emitter.MessageHandler) emitter.MessageHandler {
+	return func(c *emitter.Client, msg emitter.Message) {
+		channel := msg.Topic()
+		m.received.WithLabelValues(channel).Inc()
+		m.bytes.WithLabelValues(channel).Add(float64(len(msg.Payload())))
+
+		if m.opts.ExtractTimestamp != nil {
+			if ts, ok := m.opts.ExtractTimestamp(msg); ok {
+				m.latency.WithLabelValues(channel).Observe(time.Since(ts).Seconds())
+			}
+		}
+
+		next(c, msg)
+	}
+}
@@ -0,0 +1,100 @@
+// Package tracing provides an OpenTelemetry-backed emitter.Middleware (and
+// its emitterv5.Middleware counterpart) that starts a span around each
+// message handler, extracting the trace context the publisher attached
+// rather than always starting a new trace.
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	emitter "github.com/gmlewis/emitter-io-go/v2"
+	"github.com/gmlewis/emitter-io-go/v2/emitterv5"
+)
+
+// headerLen is how many leading bytes of a payload are scanned for an
+// embedded "traceparent: ..." header line before the actual message body.
+const headerLen = 256
+
+// Options configures Middleware and MiddlewareV5.
+type Options struct {
+	// Tracer is used to start spans. Defaults to
+	// otel.Tracer("github.com/gmlewis/emitter-io-go/v2").
+	Tracer trace.Tracer
+}
+
+func tracerFrom(opts Options) trace.Tracer {
+	if opts.Tracer != nil {
+		return opts.Tracer
+	}
+	return otel.Tracer("github.com/gmlewis/emitter-io-go/v2")
+}
+
+// Middleware returns an emitter.Middleware, for the v3.1.1 client, that
+// starts a span named after the message's channel around the rest of the
+// handler chain. Since v3.1.1 has no user properties, the trace context is
+// extracted from a "traceparent: ..." header line in the first headerLen
+// bytes of the payload, if present; otherwise a new trace is started.
+func Middleware(opts Options) emitter.Middleware {
+	tracer := tracerFrom(opts)
+
+	return func(next emitter.MessageHandler) emitter.MessageHandler {
+		return func(c *emitter.Client, msg emitter.Message) {
+			ctx := extractFromPayload(context.Background(), msg.Payload())
+			_, span := tracer.Start(ctx, "emitter.message "+msg.Topic())
+			defer span.End()
+
+			next(c, msg)
+		}
+	}
+}
+
+// MiddlewareV5 returns an emitterv5.Middleware that starts a span named
+// after the message's channel around the rest of the handler chain. The
+// trace context is extracted from the "traceparent" MQTT v5 user property
+// when the publisher set one, falling back to scanning the payload the
+// same way Middleware does.
+func MiddlewareV5(opts Options) emitterv5.Middleware {
+	tracer := tracerFrom(opts)
+
+	return func(next emitterv5.MessageHandler) emitterv5.MessageHandler {
+		return func(c *emitterv5.Client, msg emitterv5.Message) {
+			ctx := context.Background()
+			if tp, ok := msg.UserProperties()["traceparent"]; ok {
+				ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier{"traceparent": tp})
+			} else {
+				ctx = extractFromPayload(ctx, msg.Payload())
+			}
+
+			_, span := tracer.Start(ctx, "emitter.message "+msg.Topic())
+			defer span.End()
+
+			next(c, msg)
+		}
+	}
+}
+
+// extractFromPayload recovers a propagated trace context from a
+// "traceparent: ..." header line in the first headerLen bytes of payload.
+func extractFromPayload(ctx context.Context, payload []byte) context.Context {
+	if len(payload) > headerLen {
+		payload = payload[:headerLen]
+	}
+
+	const prefix = "traceparent: "
+	i := strings.Index(string(payload), prefix)
+	if i < 0 {
+		return ctx
+	}
+
+	line := string(payload[i+len(prefix):])
+	if j := strings.IndexByte(line, '\n'); j >= 0 {
+		line = line[:j]
+	}
+
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier{"traceparent": line})
+}
@@ -0,0 +1,181 @@
+package emitter
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/gorilla/websocket"
+
+	"github.com/gmlewis/emitter-io-go/v2/internal/clientopts"
+)
+
+var _ clientopts.Target = (*Client)(nil)
+
+// SetBrokers implements clientopts.Target so that WithBrokers can be shared
+// between this package and the v5 client in package emitterv5.
+func (c *Client) SetBrokers(urls []string) {
+	for _, u := range urls {
+		c.opts.AddBroker(u)
+	}
+}
+
+// WithBrokers sets one or more broker URLs that the client will attempt to
+// connect to. tcp://, ssl://, ws:// and wss:// schemes are all accepted and
+// passed straight through to the underlying AddBroker, which dispatches on
+// scheme to pick the transport; emitter.io cloud brokers on :443 require
+// wss://.
+func WithBrokers(urls ...string) func(*Client) {
+	shared := clientopts.WithBrokers(urls...)
+	return func(c *Client) { shared(c) }
+}
+
+// WithCredentials sets the username and password to authenticate with the
+// broker, in addition to (or instead of) an emitter channel key.
+func WithCredentials(user, pass string) func(*Client) {
+	return func(c *Client) {
+		c.opts.SetUsername(user)
+		c.opts.SetPassword(pass)
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for ssl:// and wss://
+// brokers, e.g. to pin a CA certificate or present a client certificate.
+func WithTLSConfig(config *tls.Config) func(*Client) {
+	return func(c *Client) { c.opts.SetTLSConfig(config) }
+}
+
+// WithClientCert loads a PEM-encoded certificate/key pair and configures
+// the client for mutual TLS. emitter.io cloud brokers accept client
+// certificates on the same :443 wss:// endpoint used for TLS.
+func WithClientCert(certFile, keyFile string) func(*Client) {
+	return func(c *Client) {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			c.configErr = fmt.Errorf("emitter: unable to load client certificate: %w", err)
+			return
+		}
+
+		config := c.opts.TLSConfig.Clone()
+		if config == nil {
+			config = &tls.Config{}
+		}
+		config.Certificates = append(config.Certificates, cert)
+		c.opts.SetTLSConfig(config)
+	}
+}
+
+// WithWebsocketHeaders sets the extra HTTP headers sent during the
+// websocket handshake, used for ws:// and wss:// brokers.
+func WithWebsocketHeaders(headers http.Header) func(*Client) {
+	return func(c *Client) { c.opts.SetHTTPHeaders(headers) }
+}
+
+// WithWill sets the last will and testament message the broker publishes
+// on the client's behalf if it disconnects ungracefully.
+func WithWill(topic string, payload []byte, qos byte, retained bool) func(*Client) {
+	return func(c *Client) { c.opts.SetWill(topic, string(payload), qos, retained) }
+}
+
+// WithHTTPProxy routes the connection to the broker through an HTTP(S)
+// proxy, using a CONNECT tunnel. This applies to tcp://, ssl://, ws:// and
+// wss:// brokers alike: for ws:// and wss:// the websocket upgrade
+// handshake is performed over the tunnel, since SetCustomOpenConnectionFn
+// replaces paho's own openConnection (which would otherwise do it).
+// Connections made through the proxy do not use the WithTLSConfig or
+// WithClientCert configuration, nor the WithWebsocketHeaders headers:
+// ssl:// uses a default TLS configuration for the broker's host name, and
+// the websocket handshake sends no extra headers.
+func WithHTTPProxy(proxy *url.URL) func(*Client) {
+	return func(c *Client) {
+		c.opts.SetCustomOpenConnectionFn(func(uri *url.URL, _ mqtt.ClientOptions) (net.Conn, error) {
+			return dialThroughProxy(proxy, uri)
+		})
+	}
+}
+
+// dialThroughProxy connects to target via proxy, using an HTTP CONNECT
+// tunnel. For ws:// and wss:// targets the websocket handshake (and, for
+// wss, the TLS handshake) is performed over the tunnel; for tcp:// and
+// ssl:// it hands back the tunneled connection directly, TLS-wrapped if
+// required.
+func dialThroughProxy(proxy, target *url.URL) (net.Conn, error) {
+	switch target.Scheme {
+	case "ws", "wss":
+		return dialWebsocketThroughProxy(proxy, target)
+	default:
+		return dialTCPThroughProxy(proxy, target)
+	}
+}
+
+// dialTCPThroughProxy opens a TCP connection to target's host via proxy,
+// issuing an HTTP CONNECT request and wrapping the result in TLS if the
+// target scheme requires it.
+func dialTCPThroughProxy(proxy, target *url.URL) (net.Conn, error) {
+	conn, err := connectTunnel(proxy, target.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	switch target.Scheme {
+	case "ssl", "tls":
+		return tls.Client(conn, &tls.Config{ServerName: target.Hostname()}), nil
+	default:
+		return conn, nil
+	}
+}
+
+// dialWebsocketThroughProxy tunnels a websocket connection to target
+// through proxy: the CONNECT tunnel supplies the raw TCP (or, for wss, the
+// as-yet-untyped) connection that gorilla/websocket's Dialer then performs
+// the TLS (if wss) and HTTP upgrade handshakes over.
+func dialWebsocketThroughProxy(proxy, target *url.URL) (net.Conn, error) {
+	dialer := &websocket.Dialer{
+		NetDialContext: func(_ context.Context, _, addr string) (net.Conn, error) {
+			return connectTunnel(proxy, addr)
+		},
+	}
+
+	conn, _, err := dialer.Dial(target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return newWsConn(conn), nil
+}
+
+// connectTunnel opens a TCP connection to proxy and issues an HTTP CONNECT
+// request for targetHostPort, returning the tunneled connection once the
+// proxy has confirmed it.
+func connectTunnel(proxy *url.URL, targetHostPort string) (net.Conn, error) {
+	conn, err := net.Dial("tcp", proxy.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: targetHostPort},
+		Host:   targetHostPort,
+		Header: make(http.Header),
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("emitter: proxy CONNECT failed: %s", resp.Status)
+	}
+	return conn, nil
+}
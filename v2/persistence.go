@@ -0,0 +1,102 @@
+package emitter
+
+import (
+	"sync"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// Persistence is implemented by any backing store that durably persists
+// in-flight MQTT messages (QoS 1/2) as well as the request/response
+// correlation state that request() and onResponse() use to match a
+// keygen/link/me reply back to the caller waiting on it. It embeds the
+// paho Store interface so a Persistence can be installed directly via
+// mqtt.ClientOptions.SetStore.
+//
+// The default, installed when no WithPersistence option is supplied, is
+// an in-memory store (see newMemoryPersistence). Use FilePersistence for QoS 1/2
+// durability across process restarts, or supply a custom implementation
+// for resource-constrained devices that want a no-op store.
+type Persistence interface {
+	mqtt.Store
+	PutCallback(id uint16) chan interface{}
+	NotifyResponse(id uint16, resp interface{}) bool
+}
+
+// WithPersistence overrides the default in-memory Persistence with p. The
+// MQTT-level message store is wired into the underlying paho client via
+// mqtt.ClientOptions.SetStore; the request/response correlation layer
+// routes through the same value regardless of how it backs QoS state.
+func WithPersistence(p Persistence) func(*Client) {
+	return func(c *Client) {
+		c.store = p
+		c.opts.SetStore(p)
+	}
+}
+
+// requestCallbacks is the in-memory request/response correlation map
+// shared by every Persistence implementation: a keygen/link/me request is
+// never worth persisting across a restart, since the caller that issued it
+// is long gone by the time the process comes back up.
+type requestCallbacks struct {
+	sync.Mutex
+	pending map[uint16]chan interface{}
+}
+
+// PutCallback implements Persistence.
+func (r *requestCallbacks) PutCallback(id uint16) chan interface{} {
+	r.Lock()
+	defer r.Unlock()
+	if r.pending == nil {
+		r.pending = make(map[uint16]chan interface{})
+	}
+
+	ch := make(chan interface{}, 1)
+	r.pending[id] = ch
+	return ch
+}
+
+// NotifyResponse implements Persistence.
+func (r *requestCallbacks) NotifyResponse(id uint16, resp interface{}) bool {
+	r.Lock()
+	ch, ok := r.pending[id]
+	if ok {
+		delete(r.pending, id)
+	}
+	r.Unlock()
+
+	if !ok {
+		return false
+	}
+
+	ch <- resp
+	return true
+}
+
+// memoryPersistence delegates MQTT message state to paho's in-memory
+// MemoryStore, the default installed by NewClient when no WithPersistence
+// option is supplied.
+type memoryPersistence struct {
+	mqtt.Store
+	requestCallbacks
+}
+
+// newMemoryPersistence returns a Persistence backed by an in-memory store;
+// QoS 1/2 message state does not survive a process restart.
+func newMemoryPersistence() Persistence {
+	return &memoryPersistence{Store: mqtt.NewMemoryStore()}
+}
+
+// filePersistence delegates MQTT message state to a paho FileStore on disk,
+// while keeping the request/response correlation map in memory.
+type filePersistence struct {
+	mqtt.Store
+	requestCallbacks
+}
+
+// FilePersistence returns a Persistence that durably stores QoS 1/2
+// message state in dir, surviving process restarts. dir is created if it
+// does not already exist.
+func FilePersistence(dir string) Persistence {
+	return &filePersistence{Store: mqtt.NewFileStore(dir)}
+}
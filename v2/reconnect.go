@@ -0,0 +1,152 @@
+package emitter
+
+import (
+	"sync/atomic"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// WithMessageBuffer sets the depth of the channel paho uses to buffer
+// incoming and outgoing messages while the client is disconnected, mapping
+// to mqtt.ClientOptions.SetMessageChannelDepth. A deeper buffer lets
+// Publish keep accepting messages for longer while offline, at the cost of
+// using more memory.
+func WithMessageBuffer(size uint) func(*Client) {
+	return func(c *Client) {
+		c.opts.SetMessageChannelDepth(size)
+		c.opts.SetConnectRetry(true)
+	}
+}
+
+// WithMaxReconnectInterval sets the upper bound on the exponential backoff
+// paho uses between reconnect attempts, mapping to
+// mqtt.ClientOptions.SetMaxReconnectInterval.
+func WithMaxReconnectInterval(d time.Duration) func(*Client) {
+	return func(c *Client) { c.opts.SetMaxReconnectInterval(d) }
+}
+
+// ReconnectHandler is called every time the underlying MQTT client begins a
+// reconnect attempt, before the attempt is made. attempt is 1 on the first
+// retry after a disconnect, and backoff is the client's configured
+// MaxReconnectInterval (see WithMaxReconnectInterval), the upper bound on
+// paho's wait between attempts; the interval paho actually waits before
+// the next attempt is not reported.
+type ReconnectHandler func(c *Client, attempt int, backoff time.Duration)
+
+// subscription records the parameters of a Subscribe call so it can be
+// replayed after a reconnect; emitter.io channel keys can change format
+// between sessions, so paho's own CleanSession=false resume isn't enough.
+type subscription struct {
+	key     string
+	channel string
+	handler MessageHandler
+	options []Option
+}
+
+// activeLink records the parameters of a CreateLink/CreatePrivateLink call
+// so the link can be re-fetched after a reconnect.
+type activeLink struct {
+	key     string
+	channel string
+	name    string
+	private bool
+	handler MessageHandler
+}
+
+// OnReconnecting sets the function to be called when the client begins a
+// reconnect attempt after losing its connection.
+func (c *Client) OnReconnecting(handler ReconnectHandler) {
+	c.reconnecting = handler
+}
+
+// onReconnecting adapts paho's mqtt.ReconnectHandler to ReconnectHandler,
+// tracking the attempt number ourselves since paho doesn't report it.
+func (c *Client) onReconnecting(_ mqtt.Client, opts *mqtt.ClientOptions) {
+	attempt := int(atomic.AddUint32(&c.reconnectAttempts, 1))
+	if c.reconnecting != nil {
+		c.reconnecting(c, attempt, opts.MaxReconnectInterval)
+	}
+}
+
+// resubscribe re-issues every Subscribe and CreateLink call made so far,
+// after a reconnect. It runs on the connection's own goroutine, so errors
+// are routed to the ErrorHandler rather than returned.
+func (c *Client) resubscribe() {
+	atomic.StoreUint32(&c.reconnectAttempts, 0)
+
+	c.subsMu.Lock()
+	subs := make([]subscription, 0, len(c.subs))
+	for _, s := range c.subs {
+		subs = append(subs, s)
+	}
+	c.subsMu.Unlock()
+
+	for _, s := range subs {
+		if err := c.Subscribe(s.key, s.channel, s.handler, s.options...); err != nil && c.errors != nil {
+			c.errors(c, Error{Message: "emitter: resubscribe failed for " + s.channel})
+		}
+	}
+
+	c.subsMu.Lock()
+	links := make([]activeLink, 0, len(c.links))
+	for _, l := range c.links {
+		links = append(links, l)
+	}
+	c.subsMu.Unlock()
+
+	// CreateLink/CreatePrivateLink re-track the link themselves on success,
+	// so the loop below doesn't need to (and the map already de-duplicates
+	// by name across repeated reconnects).
+
+	for _, l := range links {
+		var err error
+		if l.private {
+			_, err = c.CreatePrivateLink(l.key, l.channel, l.name, l.handler)
+		} else {
+			_, err = c.CreateLink(l.key, l.channel, l.name, l.handler)
+		}
+		if err != nil && c.errors != nil {
+			c.errors(c, Error{Message: "emitter: re-fetch of link " + l.name + " failed"})
+		}
+	}
+}
+
+// trackSubscription records a Subscribe call for replay after a reconnect.
+func (c *Client) trackSubscription(key, channel string, handler MessageHandler, options []Option) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	if c.subs == nil {
+		c.subs = make(map[string]subscription)
+	}
+	c.subs[channel] = subscription{key: key, channel: channel, handler: handler, options: options}
+}
+
+// untrackSubscription forgets a channel subscribed via Subscribe, called
+// from Unsubscribe.
+func (c *Client) untrackSubscription(channel string) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	delete(c.subs, channel)
+}
+
+// trackLink records a CreateLink/CreatePrivateLink call for replay after a
+// reconnect, keyed by link name so that re-tracking the same link (as
+// happens every time resubscribe() re-fetches it) overwrites the existing
+// entry instead of accumulating a duplicate.
+func (c *Client) trackLink(key, channel, name string, private bool, handler MessageHandler) {
+	c.subsMu.Lock()
+	defer c.subsMu.Unlock()
+	if c.links == nil {
+		c.links = make(map[string]activeLink)
+	}
+	c.links[name] = activeLink{key: key, channel: channel, name: name, private: private, handler: handler}
+}
+
+// PendingMessages returns the number of Publish calls that have been
+// issued but not yet acknowledged by the broker, e.g. because the client is
+// currently disconnected and buffering them. Callers can use this to
+// decide whether to keep publishing or to drop messages instead.
+func (c *Client) PendingMessages() int {
+	return int(atomic.LoadInt32(&c.pending))
+}
@@ -0,0 +1,85 @@
+package emitter
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+func TestTrackAndUntrackSubscription(t *testing.T) {
+	c := &Client{}
+	handler := func(*Client, Message) {}
+
+	c.trackSubscription("key1", "a/b/c", handler, []Option{WithTTL(60)})
+	if len(c.subs) != 1 {
+		t.Fatalf("expected 1 tracked subscription, got %d", len(c.subs))
+	}
+	if sub := c.subs["a/b/c"]; sub.key != "key1" || sub.channel != "a/b/c" {
+		t.Errorf("tracked subscription = %+v, want key=key1 channel=a/b/c", sub)
+	}
+
+	c.untrackSubscription("a/b/c")
+	if len(c.subs) != 0 {
+		t.Errorf("expected subscription to be forgotten after untrackSubscription, got %d remaining", len(c.subs))
+	}
+}
+
+func TestTrackLink(t *testing.T) {
+	c := &Client{}
+	handler := func(*Client, Message) {}
+
+	c.trackLink("key1", "a/b/c", "mylink", true, handler)
+	if len(c.links) != 1 {
+		t.Fatalf("expected 1 tracked link, got %d", len(c.links))
+	}
+	if l := c.links["mylink"]; l.name != "mylink" || !l.private {
+		t.Errorf("tracked link = %+v, want name=mylink private=true", l)
+	}
+}
+
+func TestTrackLinkOverwritesExistingEntry(t *testing.T) {
+	c := &Client{}
+	handler := func(*Client, Message) {}
+
+	c.trackLink("key1", "a/b/c", "mylink", true, handler)
+	c.trackLink("key1", "a/b/c", "mylink", true, handler)
+	c.trackLink("key1", "a/b/c", "mylink", true, handler)
+
+	if len(c.links) != 1 {
+		t.Errorf("re-tracking the same link name left %d entries, want 1", len(c.links))
+	}
+}
+
+func TestResubscribeResetsAttemptCounter(t *testing.T) {
+	c := &Client{}
+	atomic.StoreUint32(&c.reconnectAttempts, 3)
+
+	c.resubscribe() // no subs/links tracked, so no call reaches the (nil) connection
+
+	if got := atomic.LoadUint32(&c.reconnectAttempts); got != 0 {
+		t.Errorf("reconnectAttempts after resubscribe = %d, want 0", got)
+	}
+}
+
+func TestOnReconnectingInvokesHandlerWithIncreasingAttempts(t *testing.T) {
+	c := &Client{}
+
+	var attempts []int
+	c.OnReconnecting(func(_ *Client, attempt int, _ time.Duration) {
+		attempts = append(attempts, attempt)
+	})
+
+	opts := mqtt.NewClientOptions()
+	c.onReconnecting(nil, opts)
+
+	if len(attempts) != 1 || attempts[0] != 1 {
+		t.Fatalf("attempts after first reconnect = %v, want [1]", attempts)
+	}
+
+	c.onReconnecting(nil, opts)
+	if len(attempts) != 2 || attempts[1] != 2 {
+		t.Fatalf("attempts after second reconnect = %v, want [1 2]", attempts)
+	}
+}
@@ -0,0 +1,65 @@
+package emitter
+
+import (
+	"io"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// wsConn adapts a gorilla/websocket connection to the net.Conn interface
+// paho.mqtt.golang's packet reader/writer expects, reading and writing
+// MQTT packets as binary websocket messages. gorilla/websocket has no
+// built-in net.Conn adapter, so Read buffers across websocket message
+// boundaries itself. Used by dialWebsocketThroughProxy, since
+// SetCustomOpenConnectionFn bypasses paho's own websocket dialing.
+type wsConn struct {
+	*websocket.Conn
+	reader io.Reader
+}
+
+func newWsConn(conn *websocket.Conn) *wsConn {
+	return &wsConn{Conn: conn}
+}
+
+// Read implements net.Conn, pulling from the current websocket message
+// until it's exhausted and then waiting for the next one.
+func (c *wsConn) Read(b []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			_, r, err := c.Conn.NextReader()
+			if err != nil {
+				return 0, err
+			}
+			c.reader = r
+		}
+
+		n, err := c.reader.Read(b)
+		if err == io.EOF {
+			c.reader = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+// Write implements net.Conn, sending b as a single binary websocket
+// message.
+func (c *wsConn) Write(b []byte) (int, error) {
+	if err := c.Conn.WriteMessage(websocket.BinaryMessage, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// SetDeadline implements net.Conn; gorilla/websocket has no combined
+// deadline setter, so apply it to both directions.
+func (c *wsConn) SetDeadline(t time.Time) error {
+	if err := c.Conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.Conn.SetWriteDeadline(t)
+}
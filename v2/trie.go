@@ -0,0 +1,67 @@
+package emitter
+
+import (
+	"strings"
+	"sync"
+)
+
+// trie is a simple registry of per-channel message handlers, keyed by the
+// literal channel the caller subscribed to. It supports the same trailing
+// wildcard ("a/b/*") and single-level wildcard ("a/+/c") segments that
+// emitter.io channels use.
+type trie struct {
+	sync.RWMutex
+	handlers map[string]MessageHandler
+}
+
+func newTrie() *trie {
+	return &trie{handlers: make(map[string]MessageHandler)}
+}
+
+// AddHandler registers a handler for a channel.
+func (t *trie) AddHandler(channel string, h MessageHandler) {
+	t.Lock()
+	defer t.Unlock()
+	t.handlers[channel] = h
+}
+
+// RemoveHandler removes the handler registered for a channel, if any.
+func (t *trie) RemoveHandler(channel string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.handlers, channel)
+}
+
+// Lookup returns every handler whose registered channel matches the topic.
+func (t *trie) Lookup(topic string) []MessageHandler {
+	t.RLock()
+	defer t.RUnlock()
+
+	var matches []MessageHandler
+	for channel, h := range t.handlers {
+		if matchChannel(channel, topic) {
+			matches = append(matches, h)
+		}
+	}
+	return matches
+}
+
+// matchChannel reports whether topic satisfies the subscription pattern in
+// channel, honoring the trailing "*" and single-level "+" wildcards.
+func matchChannel(channel, topic string) bool {
+	subParts := strings.Split(strings.TrimSuffix(channel, "/"), "/")
+	topicParts := strings.Split(strings.TrimSuffix(topic, "/"), "/")
+
+	for i, part := range subParts {
+		if part == "*" {
+			return true
+		}
+		if i >= len(topicParts) {
+			return false
+		}
+		if part != "+" && part != topicParts[i] {
+			return false
+		}
+	}
+	return len(subParts) == len(topicParts)
+}
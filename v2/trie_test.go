@@ -0,0 +1,52 @@
+package emitter
+
+import "testing"
+
+func TestMatchChannel(t *testing.T) {
+	tests := []struct {
+		channel string
+		topic   string
+		want    bool
+	}{
+		{"a/b/c", "a/b/c", true},
+		{"a/b/c", "a/b/d", false},
+		{"a/b/*", "a/b/c", true},
+		{"a/b/*", "a/b/c/d", true},
+		{"a/b/*", "a/c/d", false},
+		{"a/+/c", "a/b/c", true},
+		{"a/+/c", "a/b/d", false},
+		{"a/+/c", "a/x/y/c", false},
+		{"a/b/", "a/b", true},
+		{"a/b", "a/b/", true},
+	}
+
+	for _, tt := range tests {
+		if got := matchChannel(tt.channel, tt.topic); got != tt.want {
+			t.Errorf("matchChannel(%q, %q) = %v, want %v", tt.channel, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestTrieLookup(t *testing.T) {
+	tr := newTrie()
+
+	var calledA, calledWildcard bool
+	tr.AddHandler("a/b/c", func(*Client, Message) { calledA = true })
+	tr.AddHandler("a/b/*", func(*Client, Message) { calledWildcard = true })
+
+	handlers := tr.Lookup("a/b/c")
+	if len(handlers) != 2 {
+		t.Fatalf("Lookup returned %d handlers, want 2", len(handlers))
+	}
+	for _, h := range handlers {
+		h(nil, nil)
+	}
+	if !calledA || !calledWildcard {
+		t.Errorf("expected both handlers to be called, got calledA=%v calledWildcard=%v", calledA, calledWildcard)
+	}
+
+	tr.RemoveHandler("a/b/c")
+	if got := len(tr.Lookup("a/b/c")); got != 1 {
+		t.Errorf("after RemoveHandler, Lookup returned %d handlers, want 1", got)
+	}
+}
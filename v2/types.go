@@ -0,0 +1,107 @@
+package emitter
+
+// MessageHandler is an event handler function for incoming messages.
+type MessageHandler func(*Client, Message)
+
+// ConnectHandler is called when the client successfully connects, both on
+// initial connection and after a reconnect.
+type ConnectHandler func(*Client)
+
+// DisconnectHandler is called when the client loses connection with the
+// broker.
+type DisconnectHandler func(*Client, error)
+
+// PresenceHandler is called when a presence event is received.
+type PresenceHandler func(*Client, PresenceEvent)
+
+// ErrorHandler is called when an emitter-specific error is received that
+// isn't otherwise correlated to a pending keygen/link/me request.
+type ErrorHandler func(*Client, Error)
+
+// Response is implemented by every emitter response payload that carries a
+// request ID, used to match a PUBLISH reply to the request that triggered
+// it. The ID is the same numeric MQTT packet identifier the client used
+// when publishing the request; emitter.io's broker echoes it back as "req".
+type Response interface {
+	RequestID() uint16
+}
+
+// Error represents an emitter-specific error response.
+type Error struct {
+	ReqID   uint16 `json:"req"`
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// RequestID implements Response.
+func (e *Error) RequestID() uint16 { return e.ReqID }
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// keygenRequest requests a new channel key.
+type keygenRequest struct {
+	Key     string `json:"key"`
+	Channel string `json:"channel"`
+	Type    string `json:"type"`
+	TTL     int    `json:"ttl"`
+}
+
+// keyGenResponse is the broker's reply to a keygenRequest.
+type keyGenResponse struct {
+	ReqID   uint16 `json:"req"`
+	Status  int    `json:"status"`
+	Key     string `json:"key"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// RequestID implements Response.
+func (r *keyGenResponse) RequestID() uint16 { return r.ReqID }
+
+// presenceRequest requests presence information on a channel.
+type presenceRequest struct {
+	Key     string `json:"key"`
+	Channel string `json:"channel"`
+	Status  bool   `json:"status"`
+	Changes bool   `json:"changes"`
+}
+
+// PresenceEvent represents a presence event received from the broker.
+type PresenceEvent struct {
+	Time    int64    `json:"time"`
+	Event   string   `json:"event"`
+	Channel string   `json:"channel"`
+	Who     []string `json:"who,omitempty"`
+}
+
+// linkRequest requests creation of a link.
+type linkRequest struct {
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+	Channel   string `json:"channel"`
+	Subscribe bool   `json:"subscribe"`
+	Private   bool   `json:"private"`
+}
+
+// Link represents an emitter link.
+type Link struct {
+	ReqID   uint16 `json:"req"`
+	Status  int    `json:"status"`
+	Name    string `json:"name"`
+	Channel string `json:"channel"`
+}
+
+// RequestID implements Response.
+func (l *Link) RequestID() uint16 { return l.ReqID }
+
+// meResponse carries the client's own identity, as returned by "emitter/me/".
+type meResponse struct {
+	ReqID uint16   `json:"req"`
+	ID    string   `json:"id"`
+	Links []string `json:"links,omitempty"`
+}
+
+// RequestID implements Response.
+func (r *meResponse) RequestID() uint16 { return r.ReqID }